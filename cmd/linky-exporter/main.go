@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/log"
 	"github.com/syberalexis/linky-exporter/pkg/core"
@@ -11,11 +13,16 @@ import (
 )
 
 var (
-	version         = "dev"
-	defaultPort     = 9901
-	defaultAddress  = "0.0.0.0"
-	defaultFile     = "/dev/serial0"
-	defaultBaudRate = 1200
+	version              = "dev"
+	defaultPort          = 9901
+	defaultAddress       = "0.0.0.0"
+	defaultFile          = "/dev/serial0"
+	defaultBaudRate      = 1200
+	defaultMQTTInterval  = 10 * time.Second
+	defaultMQTTTopic     = "linky"
+	defaultMQTTDiscovery = "homeassistant"
+	defaultTicMode       = "standard"
+	defaultStaleAfter    = 2 * time.Minute
 )
 
 // Linky-exporter command main
@@ -33,6 +40,16 @@ func main() {
 	app.Flag("baud", "Baud rate").Default(fmt.Sprintf("%d", defaultBaudRate)).Short('b').IntVar(&exporter.BaudRate)
 	app.Flag("file", "Listen file").Default(fmt.Sprintf("%s", defaultFile)).Short('f').StringVar(&exporter.File)
 	app.Flag("port", "Listen port").Default(fmt.Sprintf("%d", defaultPort)).Short('p').IntVar(&exporter.Port)
+	app.Flag("tic-mode", "TIC mode of the meter (standard, historique or auto)").Default(defaultTicMode).EnumVar(&exporter.TicMode, "standard", "historique", "auto")
+	app.Flag("stale-after", "How long since the last valid TIC frame before linky_up reports 0").Default(defaultStaleAfter.String()).DurationVar(&exporter.StaleAfter)
+
+	// MQTT
+	app.Flag("mqtt-broker", "MQTT broker URI (e.g. tcp://localhost:1883), enables MQTT publishing when set").StringVar(&exporter.MQTTBroker)
+	app.Flag("mqtt-topic-prefix", "MQTT topic prefix to publish values under").Default(defaultMQTTTopic).StringVar(&exporter.MQTTTopicPrefix)
+	app.Flag("mqtt-username", "MQTT username").StringVar(&exporter.MQTTUsername)
+	app.Flag("mqtt-password", "MQTT password").StringVar(&exporter.MQTTPassword)
+	app.Flag("mqtt-interval", "Interval between two MQTT publications").Default(defaultMQTTInterval.String()).DurationVar(&exporter.MQTTInterval)
+	app.Flag("mqtt-discovery-prefix", "Home Assistant MQTT discovery prefix").Default(defaultMQTTDiscovery).StringVar(&exporter.MQTTDiscoveryPrefix)
 
 	// Parsing
 	args, err := app.Parse(os.Args[1:])
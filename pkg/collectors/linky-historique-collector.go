@@ -0,0 +1,87 @@
+package collectors
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TicMode selects which TIC (Télé-Information Client) framing LinkyCollector
+// expects on the serial line.
+type TicMode string
+
+const (
+	// TicModeStandard is the newer framing (tab separated groups, checksum as
+	// its own field) used by Linky meters since the "mode standard" rollout.
+	TicModeStandard TicMode = "standard"
+	// TicModeHistorique is the older framing (space separated groups, trailing
+	// checksum character) still emitted by many deployed meters at 1200 bauds.
+	TicModeHistorique TicMode = "historique"
+	// TicModeAuto detects the framing from the separator of the first
+	// complete group seen on the line.
+	TicModeAuto TicMode = "auto"
+)
+
+// detectTicMode inspects a raw TIC line and returns the mode its separator
+// belongs to, or "" when the line carries no group (e.g. the STX/ETX marker
+// line on its own).
+func detectTicMode(line string) TicMode {
+	if strings.Contains(line, string(0x09)) {
+		return TicModeStandard
+	}
+	if strings.Contains(line, string(0x20)) {
+		return TicModeHistorique
+	}
+	return ""
+}
+
+// collectHistorique writes the mode historique metrics to ch.
+func (collector *LinkyCollector) collectHistorique(ch chan<- prometheus.Metric, values *LinkyValues) {
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Base), values.Prm, "base")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Hchc), values.Prm, "hchc")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Hchp), values.Prm, "hchp")
+	ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.Iinst), values.Prm, "1")
+	ch <- prometheus.MustNewConstMetric(collector.linky_apparent_power, prometheus.GaugeValue, float64(values.Papp), values.Prm)
+	ch <- prometheus.MustNewConstMetric(collector.linky_subscribed_power_a, prometheus.GaugeValue, float64(values.Isousc), values.Prm)
+}
+
+// proceedLineHistorique parses a mode historique TIC group. It returns false
+// when a required field is missing or fails to convert.
+func (collector *LinkyCollector) proceedLineHistorique(linkyValues *LinkyValues, line string) bool {
+	data := strings.Split(line, string(0x20))
+	p := newFieldParser(data)
+
+	switch strings.ToLower(data[0]) {
+	case "adco":
+		linkyValues.Adco = p.str(1)
+		// Mode historique has no PRM field; ADCO is the closest thing to a
+		// meter identifier, so reuse it as the metrics label.
+		linkyValues.Prm = p.str(1)
+	case "optarif":
+		linkyValues.Optarif = p.str(1)
+	case "isousc":
+		linkyValues.Isousc = uint8(p.uint(1, 8))
+	case "base":
+		linkyValues.Base = uint32(p.uint(1, 32))
+	case "hchc":
+		linkyValues.Hchc = uint32(p.uint(1, 32))
+	case "hchp":
+		linkyValues.Hchp = uint32(p.uint(1, 32))
+	case "ptec":
+		linkyValues.Ptec = p.str(1)
+	case "iinst":
+		linkyValues.Iinst = uint8(p.uint(1, 8))
+	case "imax":
+		linkyValues.Imax = uint8(p.uint(1, 8))
+	case "papp":
+		linkyValues.Papp = uint16(p.uint(1, 16))
+	case "hhphc":
+		linkyValues.Hhphc = p.str(1)
+	case "motdetat":
+		linkyValues.Motdetat = p.str(1)
+	case "prm":
+		linkyValues.Prm = p.str(1)
+	}
+
+	return p.ok
+}
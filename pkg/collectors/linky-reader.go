@@ -0,0 +1,89 @@
+package collectors
+
+import (
+	"bufio"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tarm/serial"
+)
+
+// minBackoff and maxBackoff bound the delay between reconnection attempts
+// after the serial port fails to open or a read fails.
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// errNoFrameYet is returned by LatestValues before the background reader has
+// stored its first frame.
+var errNoFrameYet = errors.New("linky: no TIC frame read yet")
+
+// Start launches the background goroutine that keeps collector.snapshot up to
+// date with the latest successfully read TIC frame. It returns immediately;
+// the goroutine retries with a capped exponential backoff on any error
+// instead of giving up.
+func (collector *LinkyCollector) Start() {
+	go collector.runLoop()
+}
+
+// runLoop opens the serial port once and continuously reads TIC frames from
+// it, storing each one in collector.snapshot. It never returns: a failure to
+// open the port or a read error is logged and retried after a backoff
+// instead of crashing the exporter, since a noisy or momentarily
+// disconnected meter should not take metric scraping down with it. The port
+// is only closed and reopened when a read fails, not between frames.
+func (collector *LinkyCollector) runLoop() {
+	backoff := minBackoff
+
+	for {
+		if err := collector.readUntilError(); err != nil {
+			log.Errorf("Unable to read TIC frame, retrying in %s : %s", backoff, err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		backoff = minBackoff
+	}
+}
+
+// readUntilError opens the serial port and reads TIC frames from it in a
+// loop, publishing each as the current snapshot, until a read fails. It
+// returns that error so the caller can back off before reopening the port.
+func (collector *LinkyCollector) readUntilError() error {
+	c := &serial.Config{Name: collector.device, Baud: collector.baudRate, Size: collector.frameSize, Parity: collector.parity, StopBits: collector.stopBits}
+	stream, err := serial.OpenPort(c)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	reader := bufio.NewReader(stream)
+	for {
+		values := &LinkyValues{}
+		if err := collector.readFrame(reader, values); err != nil {
+			return err
+		}
+		collector.snapshot.Store(values)
+	}
+}
+
+// nextBackoff doubles current, capped at maxBackoff.
+func nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if next > maxBackoff {
+		return maxBackoff
+	}
+	return next
+}
+
+// LatestValues returns the most recently read TIC frame, for outputs such as
+// MQTT publishing that want the same snapshot Collect exposes to Prometheus.
+func (collector *LinkyCollector) LatestValues() (*LinkyValues, error) {
+	values := collector.snapshot.Load()
+	if values == nil {
+		return nil, errNoFrameYet
+	}
+	return values, nil
+}
@@ -1,9 +1,11 @@
 package collectors
 
 import (
-	"bufio"
+	"io"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -12,94 +14,152 @@ import (
 
 // LinkyCollector object to describe and collect metrics
 type LinkyCollector struct {
-	device                 string
-	baudRate               int
-	frameSize              byte
-	parity                 serial.Parity
-	stopBits               serial.StopBits
-	linky_info             *prometheus.Desc
-	linky_index            *prometheus.Desc
-	linky_current          *prometheus.Desc
-	linky_voltage          *prometheus.Desc
-	linky_subscribed_power *prometheus.Desc
-	linky_power            *prometheus.Desc
-	linky_load_management  *prometheus.Desc
-	linky_relays           *prometheus.Desc
-	linky_provider_day     *prometheus.Desc
+	device                   string
+	baudRate                 int
+	frameSize                byte
+	parity                   serial.Parity
+	stopBits                 serial.StopBits
+	mode                     TicMode
+	staleAfter               time.Duration
+	linky_info               *prometheus.Desc
+	linky_index              *prometheus.Desc
+	linky_current            *prometheus.Desc
+	linky_voltage            *prometheus.Desc
+	linky_subscribed_power   *prometheus.Desc
+	linky_power              *prometheus.Desc
+	linky_load_management    *prometheus.Desc
+	linky_relays             *prometheus.Desc
+	linky_provider_day       *prometheus.Desc
+	linky_apparent_power     *prometheus.Desc
+	linky_subscribed_power_a *prometheus.Desc
+	linky_tic_frames         *prometheus.Desc
+	linky_tic_last_frame     *prometheus.Desc
+	linky_last_frame_age     *prometheus.Desc
+	linky_up                 *prometheus.Desc
+
+	// STGE register bits, decoded out of the raw linky_info stge label.
+	linky_contact_sec          *prometheus.Desc
+	linky_cutoff_state         *prometheus.Desc
+	linky_overvoltage          *prometheus.Desc
+	linky_overpower            *prometheus.Desc
+	linky_tariff_direction     *prometheus.Desc
+	linky_tempo_color_today    *prometheus.Desc
+	linky_tempo_color_tomorrow *prometheus.Desc
+	linky_mobile_peak_today    *prometheus.Desc
+	linky_mobile_peak_tomorrow *prometheus.Desc
+	linky_clock_status         *prometheus.Desc
+	linky_plc_status           *prometheus.Desc
+	linky_plc_sync             *prometheus.Desc
+
+	// snapshot holds the most recently read frame. It is written by the
+	// background reader goroutine started by Start and read by Collect,
+	// decoupling Prometheus scrapes from the serial port.
+	snapshot atomic.Pointer[LinkyValues]
+
+	// Per-group TIC checksum outcome counters, read atomically from Collect.
+	ticFramesOk           uint64
+	ticFramesBadChecksum  uint64
+	ticFramesParseError   uint64
+	ticLastFrameTimestamp int64
 }
 
-// Internal linky values object to each metrics
-type linkyValues struct {
-	adsc                string
-	vtic                string
-	date                string
-	ngtf                string
-	ltarf               string
-	east                uint32
-	easf01              uint32
-	easf02              uint32
-	easf03              uint32
-	easf04              uint32
-	easf05              uint32
-	easf06              uint32
-	easf07              uint32
-	easf08              uint32
-	easf09              uint32
-	easf10              uint32
-	easd01              uint32
-	easd02              uint32
-	easd03              uint32
-	easd04              uint32
-	eait                uint32
-	erq1                uint32
-	erq2                uint32
-	erq3                uint32
-	erq4                uint32
-	irms1               uint16
-	irms2               uint16
-	irms3               uint16
-	urms1               uint16
-	urms2               uint16
-	urms3               uint16
-	pref                uint8
-	pcoup               uint8
-	sinsts              uint16
-	sinsts1             int16
-	sinsts2             int16
-	sinsts3             int16
-	sinsti              uint16
-	stge                string
-	dpm1                string
-	dpm1_timestamp      string
-	fpm1                string
-	fpm1_timestamp      string
-	dpm2                string
-	dpm2_timestamp      string
-	fpm2                string
-	fpm2_timestamp      string
-	dpm3                string
-	dpm3_timestamp      string
-	fpm3                string
-	fpm3_timestamp      string
-	msg1                string
-	msg2                string
-	prm                 string
-	relais              uint8
-	ntarf               string
-	njourf              string
-	njourf_1            string
-	pjourf_1            string
-	ppointe             string
+// LinkyValues is the set of fields parsed out of a TIC frame. It is exported so
+// that other outputs (MQTT, ...) besides the Prometheus Collect path can reuse
+// a single reader instead of re-implementing the TIC parsing.
+type LinkyValues struct {
+	Adsc             string
+	Vtic             string
+	Date             string
+	Ngtf             string
+	Ltarf            string
+	East             uint32
+	Easf01           uint32
+	Easf02           uint32
+	Easf03           uint32
+	Easf04           uint32
+	Easf05           uint32
+	Easf06           uint32
+	Easf07           uint32
+	Easf08           uint32
+	Easf09           uint32
+	Easf10           uint32
+	Easd01           uint32
+	Easd02           uint32
+	Easd03           uint32
+	Easd04           uint32
+	Eait             uint32
+	Erq1             uint32
+	Erq2             uint32
+	Erq3             uint32
+	Erq4             uint32
+	Irms1            uint16
+	Irms2            uint16
+	Irms3            uint16
+	Urms1            uint16
+	Urms2            uint16
+	Urms3            uint16
+	Pref             uint8
+	Pcoup            uint8
+	Sinsts           uint16
+	Sinsts1          int16
+	Sinsts2          int16
+	Sinsts3          int16
+	Sinsti           uint16
+	Stge             string
+	Dpm1             string
+	Dpm1Timestamp    string
+	Fpm1             string
+	Fpm1Timestamp    string
+	Dpm2             string
+	Dpm2Timestamp    string
+	Fpm2             string
+	Fpm2Timestamp    string
+	Dpm3             string
+	Dpm3Timestamp    string
+	Fpm3             string
+	Fpm3Timestamp    string
+	Msg1             string
+	Msg2             string
+	Prm              string
+	Relais           uint8
+	Ntarf            string
+	Njourf           string
+	NjourfPlus1      string
+	PjourfPlus1      string
+	Ppointe          string
+
+	// Mode historique fields, only populated when the frame was parsed as
+	// mode historique (see Mode below).
+	Adco     string
+	Optarif  string
+	Isousc   uint8
+	Base     uint32
+	Hchc     uint32
+	Hchp     uint32
+	Ptec     string
+	Iinst    uint8
+	Imax     uint8
+	Papp     uint16
+	Hhphc    string
+	Motdetat string
+
+	// Mode is the TIC mode the frame was actually parsed as ("standard" or
+	// "historique"), as resolved by LinkyCollector.readFrame.
+	Mode TicMode
 }
 
-// NewLinkyCollector method to construct LinkyCollector
-func NewLinkyCollector(device string, baudRate int, frameSize byte, parity serial.Parity, stopBits serial.StopBits) *LinkyCollector {
+// NewLinkyCollector method to construct LinkyCollector. Call Start to begin
+// reading the serial port; until the first frame is read, Collect reports
+// linky_up as 0.
+func NewLinkyCollector(device string, baudRate int, frameSize byte, parity serial.Parity, stopBits serial.StopBits, mode TicMode, staleAfter time.Duration) *LinkyCollector {
 	return &LinkyCollector{
-		device:    device,
-		baudRate:  baudRate,
-		frameSize: frameSize,
-		parity:    parity,
-		stopBits:  stopBits,
+		device:     device,
+		baudRate:   baudRate,
+		frameSize:  frameSize,
+		parity:     parity,
+		stopBits:   stopBits,
+		mode:       mode,
+		staleAfter: staleAfter,
 		linky_info: prometheus.NewDesc("linky_info",
 			"Informations textuelles du compteur",
 			[]string{"prm", "adsc", "vtic", "date", "ngtf", "ltarf", "stge", "msg1", "msg2", "ntarf"}, nil,
@@ -136,6 +196,78 @@ func NewLinkyCollector(device string, baudRate int, frameSize byte, parity seria
 			"Numéro du jour en cours, du prochain jour et de son profil",
 			[]string{"prm", "current_day", "next_day", "next_day_profile"}, nil,
 		),
+		linky_apparent_power: prometheus.NewDesc("linky_apparent_power_voltamperes",
+			"Puissance apparente en VA (mode historique)",
+			[]string{"prm"}, nil,
+		),
+		linky_subscribed_power_a: prometheus.NewDesc("linky_subscribed_power_amperes",
+			"Intensité souscrite en A (mode historique)",
+			[]string{"prm"}, nil,
+		),
+		linky_tic_frames: prometheus.NewDesc("linky_tic_frames_total",
+			"Nombre de groupes d'information TIC reçus, par résultat de validation",
+			[]string{"result"}, nil,
+		),
+		linky_tic_last_frame: prometheus.NewDesc("linky_tic_last_frame_timestamp_seconds",
+			"Horodatage Unix du dernier groupe d'information TIC valide reçu",
+			nil, nil,
+		),
+		linky_last_frame_age: prometheus.NewDesc("linky_last_frame_age_seconds",
+			"Âge en secondes du dernier groupe d'information TIC valide reçu",
+			nil, nil,
+		),
+		linky_up: prometheus.NewDesc("linky_up",
+			"1 si un groupe d'information TIC valide a été reçu dans les stale-after dernières secondes, 0 sinon",
+			nil, nil,
+		),
+		linky_contact_sec: prometheus.NewDesc("linky_contact_sec",
+			"État du contact sec, 0 = fermé 1 = ouvert (bit 0 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_cutoff_state: prometheus.NewDesc("linky_cutoff_state",
+			"État de l'organe de coupure (bits 1-3 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_overvoltage: prometheus.NewDesc("linky_overvoltage",
+			"Dépassement de la tension de référence sur une des phases, 0 = non 1 = oui (bit 6 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_overpower: prometheus.NewDesc("linky_overpower",
+			"Dépassement de la puissance de référence, 0 = non 1 = oui (bit 7 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_tariff_direction: prometheus.NewDesc("linky_tariff_direction",
+			"Sens de l'énergie active, 0 = positive 1 = négative (bit 9 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_clock_status: prometheus.NewDesc("linky_clock_status",
+			"État de l'horloge, 0 = correcte 1 = mode dégradé (bit 16 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_tempo_color_today: prometheus.NewDesc("linky_tempo_color_today",
+			"Couleur du jour contrat Tempo, 0 = inconnue 1 = bleu 2 = blanc 3 = rouge (bits 24-25 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_tempo_color_tomorrow: prometheus.NewDesc("linky_tempo_color_tomorrow",
+			"Couleur du lendemain contrat Tempo, 0 = inconnue 1 = bleu 2 = blanc 3 = rouge (bits 26-27 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_mobile_peak_tomorrow: prometheus.NewDesc("linky_mobile_peak_tomorrow",
+			"Préavis pointe mobile du lendemain, 0 = aucune sinon numéro de la pointe (bits 28-29 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_mobile_peak_today: prometheus.NewDesc("linky_mobile_peak_today",
+			"Pointe mobile en cours, 0 = aucune sinon numéro de la pointe (bits 30-31 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_plc_status: prometheus.NewDesc("linky_plc_status",
+			"État de la sortie de communication Euridis/CPL (bits 21-22 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
+		linky_plc_sync: prometheus.NewDesc("linky_plc_sync",
+			"Synchronisation du CPL, 0 = non synchronisé 1 = synchronisé (bit 23 du registre STGE)",
+			[]string{"prm"}, nil,
+		),
 	}
 }
 
@@ -150,248 +282,395 @@ func (collector *LinkyCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- collector.linky_load_management
 	ch <- collector.linky_relays
 	ch <- collector.linky_provider_day
+	ch <- collector.linky_apparent_power
+	ch <- collector.linky_subscribed_power_a
+	ch <- collector.linky_tic_frames
+	ch <- collector.linky_tic_last_frame
+	ch <- collector.linky_last_frame_age
+	ch <- collector.linky_up
+	ch <- collector.linky_contact_sec
+	ch <- collector.linky_cutoff_state
+	ch <- collector.linky_overvoltage
+	ch <- collector.linky_overpower
+	ch <- collector.linky_tariff_direction
+	ch <- collector.linky_clock_status
+	ch <- collector.linky_tempo_color_today
+	ch <- collector.linky_tempo_color_tomorrow
+	ch <- collector.linky_mobile_peak_tomorrow
+	ch <- collector.linky_mobile_peak_today
+	ch <- collector.linky_plc_status
+	ch <- collector.linky_plc_sync
 }
 
-// Collect implements required collect function for all prometheus collectors
+// Collect implements required collect function for all prometheus collectors.
+// It never touches the serial port: it only reads the snapshot kept up to
+// date by the background goroutine started with Start.
 func (collector *LinkyCollector) Collect(ch chan<- prometheus.Metric) {
-	//for each descriptor or call other functions that do so.
-	//Implement logic here to determine proper metric value to return to prometheus
-	values := linkyValues{}
-	err := collector.readSerial(&values)
-
-	if err == nil {
-		//Write latest value for each metric in the prometheus metric channel.
-		//Note that you can pass CounterValue, GaugeValue, or UntypedValue types here.
-		ch <- prometheus.MustNewConstMetric(collector.linky_info, prometheus.GaugeValue, 1, values.prm, values.adsc, values.vtic, values.date, values.ngtf, values.ltarf, values.stge, values.msg1, values.msg2, values.ntarf)
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.east), values.prm, "east")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf01), values.prm, "easf01")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf02), values.prm, "easf02")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf03), values.prm, "easf03")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf04), values.prm, "easf04")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf05), values.prm, "easf05")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf06), values.prm, "easf06")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf07), values.prm, "easf07")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf08), values.prm, "easf08")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf09), values.prm, "easf09")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easf10), values.prm, "easf10")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easd01), values.prm, "easd01")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easd02), values.prm, "easd02")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easd03), values.prm, "easd03")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.easd04), values.prm, "easd04")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.eait), values.prm, "eait")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.erq1), values.prm, "erq1")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.erq2), values.prm, "erq2")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.erq3), values.prm, "erq3")
-		ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.erq4), values.prm, "erq4")
-		ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.irms1), values.prm, "1")
-		ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.irms2), values.prm, "2")
-		ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.irms3), values.prm, "3")
-		ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.urms1), values.prm, "1")
-		ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.urms2), values.prm, "2")
-		ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.urms3), values.prm, "3")
-		ch <- prometheus.MustNewConstMetric(collector.linky_subscribed_power, prometheus.GaugeValue, float64(values.pref) * 1000, values.prm, "pref")
-		ch <- prometheus.MustNewConstMetric(collector.linky_subscribed_power, prometheus.GaugeValue, float64(values.pcoup) * 1000, values.prm, "pcoup")
-		ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.sinsts), values.prm, "drawn", "sum")
-		ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.sinsts1), values.prm, "drawn", "1")
-		ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.sinsts2), values.prm, "drawn", "2")
-		ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.sinsts3), values.prm, "drawn", "3")
-		ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.sinsti), values.prm, "injected", "sum")
-		ch <- prometheus.MustNewConstMetric(collector.linky_load_management, prometheus.GaugeValue, 1, values.prm, values.dpm1, values.dpm1_timestamp, values.fpm1, values.fpm1_timestamp, values.dpm2, values.dpm2_timestamp, values.fpm2, values.fpm2_timestamp, values.dpm3, values.dpm3_timestamp, values.fpm3, values.fpm3_timestamp, values.ppointe)
-		ch <- prometheus.MustNewConstMetric(collector.linky_relays, prometheus.GaugeValue, float64(values.relais), values.prm, "relays")
-		ch <- prometheus.MustNewConstMetric(collector.linky_provider_day, prometheus.GaugeValue, 1, values.prm, values.njourf, values.njourf_1, values.pjourf_1)
-	} else {
-		log.Errorf("Unable to read telemetry information : %s", err)
+	values := collector.snapshot.Load()
+	if values != nil {
+		if values.Mode == TicModeHistorique {
+			collector.collectHistorique(ch, values)
+		} else {
+			collector.collectStandard(ch, values)
+		}
+	}
+
+	lastFrame := atomic.LoadInt64(&collector.ticLastFrameTimestamp)
+	age := 0.0
+	up := 0.0
+	if lastFrame != 0 {
+		age = time.Since(time.Unix(lastFrame, 0)).Seconds()
+		if time.Duration(age*float64(time.Second)) <= collector.staleAfter {
+			up = 1
+		}
 	}
+
+	ch <- prometheus.MustNewConstMetric(collector.linky_tic_frames, prometheus.CounterValue, float64(atomic.LoadUint64(&collector.ticFramesOk)), "ok")
+	ch <- prometheus.MustNewConstMetric(collector.linky_tic_frames, prometheus.CounterValue, float64(atomic.LoadUint64(&collector.ticFramesBadChecksum)), "bad_checksum")
+	ch <- prometheus.MustNewConstMetric(collector.linky_tic_frames, prometheus.CounterValue, float64(atomic.LoadUint64(&collector.ticFramesParseError)), "parse_error")
+	ch <- prometheus.MustNewConstMetric(collector.linky_tic_last_frame, prometheus.GaugeValue, float64(lastFrame))
+	ch <- prometheus.MustNewConstMetric(collector.linky_last_frame_age, prometheus.GaugeValue, age)
+	ch <- prometheus.MustNewConstMetric(collector.linky_up, prometheus.GaugeValue, up)
 }
 
-// Read information from serial port
-func (collector *LinkyCollector) readSerial(linkyValues *linkyValues) error {
-	c := &serial.Config{Name: collector.device, Baud: collector.baudRate, Size: collector.frameSize, Parity: collector.parity, StopBits: collector.stopBits}
-	stream, err := serial.OpenPort(c)
-	if err != nil {
-		log.Fatal(err)
+// collectStandard writes the mode standard metrics to ch.
+func (collector *LinkyCollector) collectStandard(ch chan<- prometheus.Metric, values *LinkyValues) {
+	//Write latest value for each metric in the prometheus metric channel.
+	//Note that you can pass CounterValue, GaugeValue, or UntypedValue types here.
+	ch <- prometheus.MustNewConstMetric(collector.linky_info, prometheus.GaugeValue, 1, values.Prm, values.Adsc, values.Vtic, values.Date, values.Ngtf, values.Ltarf, values.Stge, values.Msg1, values.Msg2, values.Ntarf)
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.East), values.Prm, "east")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf01), values.Prm, "easf01")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf02), values.Prm, "easf02")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf03), values.Prm, "easf03")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf04), values.Prm, "easf04")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf05), values.Prm, "easf05")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf06), values.Prm, "easf06")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf07), values.Prm, "easf07")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf08), values.Prm, "easf08")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf09), values.Prm, "easf09")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easf10), values.Prm, "easf10")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easd01), values.Prm, "easd01")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easd02), values.Prm, "easd02")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easd03), values.Prm, "easd03")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Easd04), values.Prm, "easd04")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Eait), values.Prm, "eait")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Erq1), values.Prm, "erq1")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Erq2), values.Prm, "erq2")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Erq3), values.Prm, "erq3")
+	ch <- prometheus.MustNewConstMetric(collector.linky_index, prometheus.CounterValue, float64(values.Erq4), values.Prm, "erq4")
+	ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.Irms1), values.Prm, "1")
+	ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.Irms2), values.Prm, "2")
+	ch <- prometheus.MustNewConstMetric(collector.linky_current, prometheus.GaugeValue, float64(values.Irms3), values.Prm, "3")
+	ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.Urms1), values.Prm, "1")
+	ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.Urms2), values.Prm, "2")
+	ch <- prometheus.MustNewConstMetric(collector.linky_voltage, prometheus.GaugeValue, float64(values.Urms3), values.Prm, "3")
+	ch <- prometheus.MustNewConstMetric(collector.linky_subscribed_power, prometheus.GaugeValue, float64(values.Pref)*1000, values.Prm, "pref")
+	ch <- prometheus.MustNewConstMetric(collector.linky_subscribed_power, prometheus.GaugeValue, float64(values.Pcoup)*1000, values.Prm, "pcoup")
+	ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.Sinsts), values.Prm, "drawn", "sum")
+	ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.Sinsts1), values.Prm, "drawn", "1")
+	ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.Sinsts2), values.Prm, "drawn", "2")
+	ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.Sinsts3), values.Prm, "drawn", "3")
+	ch <- prometheus.MustNewConstMetric(collector.linky_power, prometheus.GaugeValue, float64(values.Sinsti), values.Prm, "injected", "sum")
+	ch <- prometheus.MustNewConstMetric(collector.linky_load_management, prometheus.GaugeValue, 1, values.Prm, values.Dpm1, values.Dpm1Timestamp, values.Fpm1, values.Fpm1Timestamp, values.Dpm2, values.Dpm2Timestamp, values.Fpm2, values.Fpm2Timestamp, values.Dpm3, values.Dpm3Timestamp, values.Fpm3, values.Fpm3Timestamp, values.Ppointe)
+	ch <- prometheus.MustNewConstMetric(collector.linky_relays, prometheus.GaugeValue, float64(values.Relais), values.Prm, "relays")
+	ch <- prometheus.MustNewConstMetric(collector.linky_provider_day, prometheus.GaugeValue, 1, values.Prm, values.Njourf, values.NjourfPlus1, values.PjourfPlus1)
+
+	if stge, ok := decodeStge(values.Stge); ok {
+		ch <- prometheus.MustNewConstMetric(collector.linky_contact_sec, prometheus.GaugeValue, float64(stge.contactSec), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_cutoff_state, prometheus.GaugeValue, float64(stge.cutoffState), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_overvoltage, prometheus.GaugeValue, float64(stge.overvoltage), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_overpower, prometheus.GaugeValue, float64(stge.overpower), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_tariff_direction, prometheus.GaugeValue, float64(stge.tariffDirection), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_clock_status, prometheus.GaugeValue, float64(stge.clockStatus), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_tempo_color_today, prometheus.GaugeValue, float64(stge.tempoColorToday), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_tempo_color_tomorrow, prometheus.GaugeValue, float64(stge.tempoColorTomorrow), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_mobile_peak_tomorrow, prometheus.GaugeValue, float64(stge.mobilePeakTomorrow), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_mobile_peak_today, prometheus.GaugeValue, float64(stge.mobilePeakToday), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_plc_status, prometheus.GaugeValue, float64(stge.plcStatus), values.Prm)
+		ch <- prometheus.MustNewConstMetric(collector.linky_plc_sync, prometheus.GaugeValue, float64(stge.plcSync), values.Prm)
 	}
+}
 
-	reader := bufio.NewReader(stream)
+// readFrame reads bytes from reader until a full, validated frame has been
+// parsed into linkyValues. It is decoupled from the serial port via the
+// io.ByteReader interface so the framing and checksum logic can be exercised
+// without real hardware; readUntilError is what wires it to an actual port.
+func (collector *LinkyCollector) readFrame(reader io.ByteReader, linkyValues *LinkyValues) error {
 	started := false
+	inGroup := false
+	mode := collector.mode
+	resolved := mode != TicModeAuto
+	var group []byte
+
 	for {
-		bytes, _, err := reader.ReadLine()
+		b, err := reader.ReadByte()
 		if err != nil {
 			return err
 		}
 
-		line := string(bytes)
-
-		// End loop when block ended
-		if started && strings.Contains(line, string(0x03)) {
-			break
+		switch b {
+		case 0x02: // STX: (re)start of frame, discards any partial group
+			started = true
+			inGroup = false
+			group = group[:0]
+		case 0x03: // ETX: end of frame
+			if started {
+				return nil
+			}
+		case 0x0A: // LF: start of a group
+			if started {
+				inGroup = true
+				group = group[:0]
+			}
+		case 0x0D: // CR: end of a group
+			if started && inGroup {
+				collector.proceedGroup(linkyValues, string(group), &mode, &resolved)
+				inGroup = false
+			}
+		default:
+			if started && inGroup {
+				group = append(group, b)
+			}
 		}
+	}
+}
 
-		// Start reading data when block started
-		if strings.Contains(line, string(0x02)) {
-			started = true
+// proceedGroup resolves the TIC mode if needed, validates the group checksum
+// and, if valid, hands the group payload to the mode-specific parser. Invalid
+// groups are dropped and counted instead of corrupting linkyValues.
+func (collector *LinkyCollector) proceedGroup(linkyValues *LinkyValues, group string, mode *TicMode, resolved *bool) {
+	if group == "" {
+		return
+	}
+
+	if !*resolved {
+		detected := detectTicMode(group)
+		if detected == "" {
+			return
 		}
+		*mode = detected
+		*resolved = true
+	}
+
+	payload, ok := checkChecksum(group, *mode)
+	if !ok {
+		atomic.AddUint64(&collector.ticFramesBadChecksum, 1)
+		log.Warnf("Dropping TIC group with invalid checksum: %q", group)
+		return
+	}
+
+	linkyValues.Mode = *mode
+	if !collector.proceedLine(linkyValues, payload, *mode) {
+		atomic.AddUint64(&collector.ticFramesParseError, 1)
+		return
+	}
+
+	atomic.AddUint64(&collector.ticFramesOk, 1)
+	atomic.StoreInt64(&collector.ticLastFrameTimestamp, time.Now().Unix())
+}
+
+// checkChecksum verifies the one-byte checksum terminating group and returns
+// the bytes the checksum was computed over: the group without its checksum
+// byte, and in mode historique without the separator before it as well.
+// Enedis defines two ways to
+// compute it depending on the TIC mode: method 1 (mode historique) sums every
+// byte up to, but excluding, the separator right before the checksum; method 2
+// (mode standard) includes that separator in the sum.
+func checkChecksum(group string, mode TicMode) (string, bool) {
+	raw := []byte(group)
+	if len(raw) < 2 {
+		return "", false
+	}
 
-		// Collect data
-		if started {
-			collector.proceedLine(linkyValues, line)
+	checksum := raw[len(raw)-1]
+	var summed []byte
+	if mode == TicModeHistorique {
+		if len(raw) < 3 {
+			return "", false
 		}
+		summed = raw[:len(raw)-2] // method 1: drop the separator and the checksum
+	} else {
+		summed = raw[:len(raw)-1] // method 2: drop only the checksum
+	}
+
+	sum := 0
+	for _, b := range summed {
+		sum += int(b)
+	}
+	computed := byte(sum&0x3F) + 0x20
+
+	if computed != checksum {
+		return "", false
+	}
+	return string(summed), true
+}
+
+// proceedLine dispatches a raw TIC line to the parser matching mode. It
+// returns false when a field failed to parse.
+func (collector *LinkyCollector) proceedLine(linkyValues *LinkyValues, line string, mode TicMode) bool {
+	if mode == TicModeHistorique {
+		return collector.proceedLineHistorique(linkyValues, line)
+	}
+	return collector.proceedLineStandard(linkyValues, line)
+}
+
+// fieldParser reads positional fields out of a split TIC group, tracking
+// whether every access and numeric conversion it performed succeeded.
+type fieldParser struct {
+	data []string
+	ok   bool
+}
+
+func newFieldParser(data []string) *fieldParser {
+	return &fieldParser{data: data, ok: true}
+}
+
+func (p *fieldParser) str(idx int) string {
+	if idx >= len(p.data) {
+		p.ok = false
+		return ""
 	}
-	return nil
+	return p.data[idx]
 }
 
-// Proceed line by line information
-func (collector *LinkyCollector) proceedLine(linkyValues *linkyValues, line string) {
+func (p *fieldParser) uint(idx int, bitSize int) uint64 {
+	val, err := strconv.ParseUint(p.str(idx), 10, bitSize)
+	if err != nil {
+		p.ok = false
+	}
+	return val
+}
+
+func (p *fieldParser) int(idx int, bitSize int) int64 {
+	val, err := strconv.ParseInt(p.str(idx), 10, bitSize)
+	if err != nil {
+		p.ok = false
+	}
+	return val
+}
+
+// proceedLineStandard parses a mode standard TIC group. It returns false when
+// a required field is missing or fails to convert.
+func (collector *LinkyCollector) proceedLineStandard(linkyValues *LinkyValues, line string) bool {
 	data := strings.Split(line, string(0x09))
+	p := newFieldParser(data)
 
 	switch strings.ToLower(data[0]) {
 	case "adsc":
-		linkyValues.adsc = string(data[1])
+		linkyValues.Adsc = p.str(1)
 	case "vtic":
-		linkyValues.vtic = string(data[1])
+		linkyValues.Vtic = p.str(1)
 	case "date":
-		linkyValues.date = string(data[1])
+		linkyValues.Date = p.str(1)
 	case "ngtf":
-		linkyValues.ngtf = string(data[1])
+		linkyValues.Ngtf = p.str(1)
 	case "ltarf":
-		linkyValues.ltarf = string(data[1])
+		linkyValues.Ltarf = p.str(1)
 	case "east":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.east = uint32(val)
+		linkyValues.East = uint32(p.uint(1, 32))
 	case "easf01":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf01 = uint32(val)
+		linkyValues.Easf01 = uint32(p.uint(1, 32))
 	case "easf02":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf02 = uint32(val)
+		linkyValues.Easf02 = uint32(p.uint(1, 32))
 	case "easf03":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf03 = uint32(val)
+		linkyValues.Easf03 = uint32(p.uint(1, 32))
 	case "easf04":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf04 = uint32(val)
+		linkyValues.Easf04 = uint32(p.uint(1, 32))
 	case "easf05":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf05 = uint32(val)
+		linkyValues.Easf05 = uint32(p.uint(1, 32))
 	case "easf06":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf06 = uint32(val)
+		linkyValues.Easf06 = uint32(p.uint(1, 32))
 	case "easf07":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf07 = uint32(val)
+		linkyValues.Easf07 = uint32(p.uint(1, 32))
 	case "easf08":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf08 = uint32(val)
+		linkyValues.Easf08 = uint32(p.uint(1, 32))
 	case "easf09":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf09 = uint32(val)
+		linkyValues.Easf09 = uint32(p.uint(1, 32))
 	case "easf10":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easf10 = uint32(val)
+		linkyValues.Easf10 = uint32(p.uint(1, 32))
 	case "easd01":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easd01 = uint32(val)
+		linkyValues.Easd01 = uint32(p.uint(1, 32))
 	case "easd02":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easd02 = uint32(val)
+		linkyValues.Easd02 = uint32(p.uint(1, 32))
 	case "easd03":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easd03 = uint32(val)
+		linkyValues.Easd03 = uint32(p.uint(1, 32))
 	case "easd04":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.easd04 = uint32(val)
+		linkyValues.Easd04 = uint32(p.uint(1, 32))
 	case "eait":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.eait = uint32(val)
+		linkyValues.Eait = uint32(p.uint(1, 32))
 	case "erq1":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.erq1 = uint32(val)
+		linkyValues.Erq1 = uint32(p.uint(1, 32))
 	case "erq2":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.erq2 = uint32(val)
+		linkyValues.Erq2 = uint32(p.uint(1, 32))
 	case "erq3":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.erq3 = uint32(val)
+		linkyValues.Erq3 = uint32(p.uint(1, 32))
 	case "erq4":
-		val, _ := strconv.ParseUint(data[1], 10, 32)
-		linkyValues.erq4 = uint32(val)
+		linkyValues.Erq4 = uint32(p.uint(1, 32))
 	case "irms1":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.irms1 = uint16(val)
+		linkyValues.Irms1 = uint16(p.uint(1, 16))
 	case "irms2":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.irms2 = uint16(val)
+		linkyValues.Irms2 = uint16(p.uint(1, 16))
 	case "irms3":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.irms3 = uint16(val)
+		linkyValues.Irms3 = uint16(p.uint(1, 16))
 	case "urms1":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.urms1 = uint16(val)
+		linkyValues.Urms1 = uint16(p.uint(1, 16))
 	case "urms2":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.urms2 = uint16(val)
+		linkyValues.Urms2 = uint16(p.uint(1, 16))
 	case "urms3":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.urms3 = uint16(val)
+		linkyValues.Urms3 = uint16(p.uint(1, 16))
 	case "pref":
-		val, _ := strconv.ParseUint(data[1], 10, 8)
-		linkyValues.pref = uint8(val)
+		linkyValues.Pref = uint8(p.uint(1, 8))
 	case "pcoup":
-		val, _ := strconv.ParseUint(data[1], 10, 8)
-		linkyValues.pcoup = uint8(val)
+		linkyValues.Pcoup = uint8(p.uint(1, 8))
 	case "sinsts":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.sinsts = uint16(val)
+		linkyValues.Sinsts = uint16(p.uint(1, 16))
 	case "sinsts1":
-		val, _ := strconv.ParseInt(data[1], 10, 16)
-		linkyValues.sinsts1 = int16(val)
+		linkyValues.Sinsts1 = int16(p.int(1, 16))
 	case "sinsts2":
-		val, _ := strconv.ParseInt(data[1], 10, 16)
-		linkyValues.sinsts2 = int16(val)
+		linkyValues.Sinsts2 = int16(p.int(1, 16))
 	case "sinsts3":
-		val, _ := strconv.ParseInt(data[1], 10, 16)
-		linkyValues.sinsts3 = int16(val)
+		linkyValues.Sinsts3 = int16(p.int(1, 16))
 	case "sinsti":
-		val, _ := strconv.ParseUint(data[1], 10, 16)
-		linkyValues.sinsti = uint16(val)
+		linkyValues.Sinsti = uint16(p.uint(1, 16))
 	case "stge":
-		linkyValues.stge = string(data[1])
+		linkyValues.Stge = p.str(1)
 	case "dpm1":
-		linkyValues.dpm1 = string(data[2])
-		linkyValues.dpm1_timestamp = string(data[1])
+		linkyValues.Dpm1Timestamp = p.str(1)
+		linkyValues.Dpm1 = p.str(2)
 	case "fpm1":
-		linkyValues.fpm1 = string(data[2])
-		linkyValues.fpm1_timestamp = string(data[1])
+		linkyValues.Fpm1Timestamp = p.str(1)
+		linkyValues.Fpm1 = p.str(2)
 	case "dpm2":
-		linkyValues.dpm2 = string(data[2])
-		linkyValues.dpm2_timestamp = string(data[1])
+		linkyValues.Dpm2Timestamp = p.str(1)
+		linkyValues.Dpm2 = p.str(2)
 	case "fpm2":
-		linkyValues.fpm2 = string(data[2])
-		linkyValues.fpm2_timestamp = string(data[1])
+		linkyValues.Fpm2Timestamp = p.str(1)
+		linkyValues.Fpm2 = p.str(2)
 	case "dpm3":
-		linkyValues.dpm3 = string(data[2])
-		linkyValues.dpm3_timestamp = string(data[1])
+		linkyValues.Dpm3Timestamp = p.str(1)
+		linkyValues.Dpm3 = p.str(2)
 	case "fpm3":
-		linkyValues.fpm3 = string(data[2])
-		linkyValues.fpm3_timestamp = string(data[1])
+		linkyValues.Fpm3Timestamp = p.str(1)
+		linkyValues.Fpm3 = p.str(2)
 	case "msg1":
-		linkyValues.msg1 = string(data[1])
+		linkyValues.Msg1 = p.str(1)
 	case "msg2":
-		linkyValues.msg2 = string(data[1])
+		linkyValues.Msg2 = p.str(1)
 	case "prm":
-		linkyValues.prm = string(data[1])
+		linkyValues.Prm = p.str(1)
 	case "relais":
-		val, _ := strconv.ParseUint(data[1], 10, 8)
-		linkyValues.relais = uint8(val)
+		linkyValues.Relais = uint8(p.uint(1, 8))
 	case "ntarf":
-		linkyValues.ntarf = string(data[1])
+		linkyValues.Ntarf = p.str(1)
 	case "njourf":
-		linkyValues.njourf = string(data[1])
+		linkyValues.Njourf = p.str(1)
 	case "njourf+1":
-		linkyValues.njourf_1 = string(data[1])
+		linkyValues.NjourfPlus1 = p.str(1)
 	case "pjourf+1":
-		linkyValues.pjourf_1 = string(data[1])
+		linkyValues.PjourfPlus1 = p.str(1)
 	case "ppointe":
-		linkyValues.ppointe = string(data[1])
+		linkyValues.Ppointe = p.str(1)
 	}
+
+	return p.ok
 }
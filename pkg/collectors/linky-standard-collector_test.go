@@ -0,0 +1,62 @@
+package collectors
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCheckChecksum(t *testing.T) {
+	cases := []struct {
+		name  string
+		group string
+		mode  TicMode
+		want  bool
+	}{
+		{"valid standard (method 2, includes last separator)", "PRM\t12345678901\t_", TicModeStandard, true},
+		{"corrupted standard checksum", "PRM\t12345678901\tX", TicModeStandard, false},
+		{"valid historique (method 1, excludes last separator)", "ADCO 041639000000 .", TicModeHistorique, true},
+		{"corrupted historique checksum", "ADCO 041639000000 X", TicModeHistorique, false},
+		{"too short to carry a checksum", "A", TicModeStandard, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, ok := checkChecksum(c.group, c.mode)
+			if ok != c.want {
+				t.Errorf("checkChecksum(%q, %v) = %v, want %v", c.group, c.mode, ok, c.want)
+			}
+		})
+	}
+}
+
+func TestReadFrameMidFrameSTXRestart(t *testing.T) {
+	var frame bytes.Buffer
+	frame.WriteByte(0x02)                  // STX: frame starts
+	frame.WriteByte(0x0A)                  // LF: group starts
+	frame.WriteString("GARBAGE")           // never closed by a CR
+	frame.WriteByte(0x02)                  // STX again: meter restarted mid-frame
+	frame.WriteByte(0x0A)                  // LF: group starts
+	frame.WriteString("PRM\t12345678901\t_") // valid standard group
+	frame.WriteByte(0x0D)                  // CR: group ends
+	frame.WriteByte(0x03)                  // ETX: frame ends
+
+	collector := NewLinkyCollector("", 0, 0, 0, 0, TicModeAuto, 0)
+	values := &LinkyValues{}
+
+	if err := collector.readFrame(&frame, values); err != nil {
+		t.Fatalf("readFrame returned error: %v", err)
+	}
+
+	if values.Prm != "12345678901" {
+		t.Errorf("Prm = %q, want %q (garbage before the STX restart must be discarded)", values.Prm, "12345678901")
+	}
+	if values.Mode != TicModeStandard {
+		t.Errorf("Mode = %q, want %q", values.Mode, TicModeStandard)
+	}
+	if collector.ticFramesOk != 1 {
+		t.Errorf("ticFramesOk = %d, want 1", collector.ticFramesOk)
+	}
+	if collector.ticFramesBadChecksum != 0 {
+		t.Errorf("ticFramesBadChecksum = %d, want 0", collector.ticFramesBadChecksum)
+	}
+}
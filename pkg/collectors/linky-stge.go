@@ -0,0 +1,47 @@
+package collectors
+
+import "strconv"
+
+// stgeInfo is the decoded form of the STGE register, the 32-bit status
+// bitmap reported by mode standard meters (Enedis-NOI-CPT_54E). STGE is
+// transmitted as an 8 hex digit string; bit positions below follow the
+// order Enedis documents them in.
+type stgeInfo struct {
+	contactSec         uint8
+	cutoffState        uint8
+	overvoltage        uint8
+	overpower          uint8
+	tariffDirection    uint8
+	clockStatus        uint8
+	tempoColorToday    uint8
+	tempoColorTomorrow uint8
+	mobilePeakToday    uint8
+	mobilePeakTomorrow uint8
+	plcStatus          uint8
+	plcSync            uint8
+}
+
+// decodeStge parses the hex STGE value and extracts the fields this exporter
+// surfaces as metrics. It returns false when hex is not a valid hex number,
+// in which case the returned stgeInfo is zero-valued.
+func decodeStge(hex string) (stgeInfo, bool) {
+	bits, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return stgeInfo{}, false
+	}
+
+	return stgeInfo{
+		contactSec:         uint8(bits & 0x1),
+		cutoffState:        uint8((bits >> 1) & 0x7),
+		overvoltage:        uint8((bits >> 6) & 0x1),
+		overpower:          uint8((bits >> 7) & 0x1),
+		tariffDirection:    uint8((bits >> 9) & 0x1),
+		clockStatus:        uint8((bits >> 16) & 0x1),
+		plcStatus:          uint8((bits >> 21) & 0x3),
+		plcSync:            uint8((bits >> 23) & 0x1),
+		tempoColorToday:    uint8((bits >> 24) & 0x3),
+		tempoColorTomorrow: uint8((bits >> 26) & 0x3),
+		mobilePeakTomorrow: uint8((bits >> 28) & 0x3),
+		mobilePeakToday:    uint8((bits >> 30) & 0x3),
+	}, true
+}
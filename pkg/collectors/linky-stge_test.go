@@ -0,0 +1,43 @@
+package collectors
+
+import "testing"
+
+func TestDecodeStge(t *testing.T) {
+	cases := []struct {
+		name string
+		hex  string
+		want stgeInfo
+	}{
+		{"all zero", "00000000", stgeInfo{}},
+		{"contact sec ouvert", "00000001", stgeInfo{contactSec: 1}},
+		{"cutoff ouvert sur surtension", "00000004", stgeInfo{cutoffState: 2}},
+		{"overvoltage", "00000040", stgeInfo{overvoltage: 1}},
+		{"overpower", "00000080", stgeInfo{overpower: 1}},
+		{"tariff direction négative", "00000200", stgeInfo{tariffDirection: 1}},
+		{"clock status dégradé", "00010000", stgeInfo{clockStatus: 1}},
+		{"plc status", "00600000", stgeInfo{plcStatus: 3}},
+		{"plc sync", "00800000", stgeInfo{plcSync: 1}},
+		{"tempo color today rouge", "03000000", stgeInfo{tempoColorToday: 3}},
+		{"tempo color tomorrow blanc", "08000000", stgeInfo{tempoColorTomorrow: 2}},
+		{"mobile peak tomorrow PM1", "10000000", stgeInfo{mobilePeakTomorrow: 1}},
+		{"mobile peak today PM2", "80000000", stgeInfo{mobilePeakToday: 2}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := decodeStge(c.hex)
+			if !ok {
+				t.Fatalf("decodeStge(%q) returned ok=false, want true", c.hex)
+			}
+			if got != c.want {
+				t.Errorf("decodeStge(%q) = %+v, want %+v", c.hex, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeStgeInvalidHex(t *testing.T) {
+	if _, ok := decodeStge("not-hex"); ok {
+		t.Errorf("decodeStge on invalid hex returned ok=true, want false")
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/syberalexis/linky-exporter/pkg/collectors"
+	"github.com/syberalexis/linky-exporter/pkg/mqtt"
 	"github.com/tarm/serial"
 )
 
@@ -21,15 +23,37 @@ type LinkyExporter struct {
 	FrameSize int
 	Parity    string
 	StopBits  string
+	TicMode   string
+
+	// StaleAfter is how long since the last valid TIC frame before linky_up
+	// reports 0.
+	StaleAfter time.Duration
+
+	// MQTT output, disabled unless MQTTBroker is set
+	MQTTBroker          string
+	MQTTTopicPrefix     string
+	MQTTUsername        string
+	MQTTPassword        string
+	MQTTInterval        time.Duration
+	MQTTDiscoveryPrefix string
 }
 
 // Run method to run http exporter server
 func (exporter *LinkyExporter) Run() {
 	log.Info(fmt.Sprintf("Beginning to serve on port :%d", exporter.Port))
 
+	collector := collectors.NewLinkyCollector(exporter.Device, exporter.BaudRate,
+		byte(exporter.FrameSize), parseParity(exporter.Parity), parseStopBits(exporter.StopBits), parseTicMode(exporter.TicMode),
+		exporter.StaleAfter)
+	collector.Start()
+
 	r := prometheus.NewRegistry()
-	r.MustRegister(collectors.NewLinkyCollector(exporter.Device, exporter.BaudRate,
-		byte(exporter.FrameSize), parseParity(exporter.Parity), parseStopBits(exporter.StopBits)))
+	r.MustRegister(collector)
+
+	if exporter.MQTTBroker != "" {
+		go exporter.runMQTT(collector)
+	}
+
 	//http.Handle("/metrics", promhttp.Handler())
 	handler := promhttp.HandlerFor(r, promhttp.HandlerOpts{})
 	http.Handle("/metrics", handler)
@@ -37,6 +61,20 @@ func (exporter *LinkyExporter) Run() {
 	log.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", exporter.Address, exporter.Port), nil))
 }
 
+// runMQTT connects to the configured broker and periodically publishes frames
+// read through collector, with Home Assistant discovery on the first frame
+// seen for each prm.
+func (exporter *LinkyExporter) runMQTT(collector *collectors.LinkyCollector) {
+	publisher, err := mqtt.NewPublisher(exporter.MQTTBroker, exporter.MQTTUsername, exporter.MQTTPassword,
+		exporter.MQTTTopicPrefix, exporter.MQTTDiscoveryPrefix)
+	if err != nil {
+		log.Errorf("Unable to connect to MQTT broker : %s", err)
+		return
+	}
+
+	publisher.Run(collector.LatestValues, exporter.MQTTInterval, nil)
+}
+
 func parseParity(value string) (parity serial.Parity) {
 	switch value {
 	case "ParityNone", "N":
@@ -64,6 +102,24 @@ func parseParity(value string) (parity serial.Parity) {
 	return
 }
 
+func parseTicMode(value string) (mode collectors.TicMode) {
+	switch value {
+	case string(collectors.TicModeStandard):
+		mode = collectors.TicModeStandard
+	case string(collectors.TicModeHistorique):
+		mode = collectors.TicModeHistorique
+	case string(collectors.TicModeAuto):
+		mode = collectors.TicModeAuto
+	default:
+		_, err := fmt.Fprintln(os.Stderr, "Impossible to parse TicMode named", value)
+		if err != nil {
+			log.Error(err)
+		}
+		os.Exit(3)
+	}
+	return
+}
+
 func parseStopBits(value string) (stopBits serial.StopBits) {
 	switch value {
 	case "Stop1", "1":
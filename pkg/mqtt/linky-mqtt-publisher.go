@@ -0,0 +1,170 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	log "github.com/sirupsen/logrus"
+	"github.com/syberalexis/linky-exporter/pkg/collectors"
+)
+
+// fieldSpec describes one published field: its value extractor and the Home
+// Assistant discovery metadata used to advertise it as a sensor.
+type fieldSpec struct {
+	name        string
+	deviceClass string
+	unit        string
+	stateClass  string
+	value       func(*collectors.LinkyValues) string
+}
+
+// fieldSpecs lists every field published over MQTT, in the same order as
+// LinkyCollector.Collect exposes them to Prometheus.
+var fieldSpecs = []fieldSpec{
+	{"east", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.East) }},
+	{"easf01", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf01) }},
+	{"easf02", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf02) }},
+	{"easf03", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf03) }},
+	{"easf04", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf04) }},
+	{"easf05", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf05) }},
+	{"easf06", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf06) }},
+	{"easf07", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf07) }},
+	{"easf08", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf08) }},
+	{"easf09", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf09) }},
+	{"easf10", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easf10) }},
+	{"easd01", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easd01) }},
+	{"easd02", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easd02) }},
+	{"easd03", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easd03) }},
+	{"easd04", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Easd04) }},
+	{"eait", "energy", "Wh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Eait) }},
+	{"erq1", "", "VArh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Erq1) }},
+	{"erq2", "", "VArh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Erq2) }},
+	{"erq3", "", "VArh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Erq3) }},
+	{"erq4", "", "VArh", "total_increasing", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Erq4) }},
+	{"irms1", "current", "A", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Irms1) }},
+	{"irms2", "current", "A", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Irms2) }},
+	{"irms3", "current", "A", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Irms3) }},
+	{"urms1", "voltage", "V", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Urms1) }},
+	{"urms2", "voltage", "V", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Urms2) }},
+	{"urms3", "voltage", "V", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Urms3) }},
+	{"sinsts", "apparent_power", "VA", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Sinsts) }},
+	{"sinsti", "apparent_power", "VA", "measurement", func(v *collectors.LinkyValues) string { return fmt.Sprintf("%d", v.Sinsti) }},
+}
+
+// Publisher publishes parsed Linky frames to an MQTT broker and advertises
+// Home Assistant MQTT discovery configs for each field.
+type Publisher struct {
+	client          paho.Client
+	topicPrefix     string
+	discoveryPrefix string
+	discovered      map[string]bool
+}
+
+// NewPublisher connects to broker and returns a ready-to-use Publisher.
+func NewPublisher(broker, username, password, topicPrefix, discoveryPrefix string) (*Publisher, error) {
+	opts := paho.NewClientOptions().AddBroker(broker).SetClientID("linky-exporter")
+	if username != "" {
+		opts.SetUsername(username)
+		opts.SetPassword(password)
+	}
+
+	client := paho.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	return &Publisher{
+		client:          client,
+		topicPrefix:     topicPrefix,
+		discoveryPrefix: discoveryPrefix,
+		discovered:      map[string]bool{},
+	}, nil
+}
+
+// Run periodically reads values from reader and publishes them until stop is closed.
+func (publisher *Publisher) Run(reader func() (*collectors.LinkyValues, error), interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			values, err := reader()
+			if err != nil {
+				log.Errorf("Unable to read telemetry information for MQTT publishing : %s", err)
+				continue
+			}
+			if err := publisher.Publish(values); err != nil {
+				log.Errorf("Unable to publish telemetry information to MQTT : %s", err)
+			}
+		}
+	}
+}
+
+// Publish sends every field of values to <topicPrefix>/<prm>/<field> and, the
+// first time a given prm is seen, publishes its Home Assistant discovery configs.
+func (publisher *Publisher) Publish(values *collectors.LinkyValues) error {
+	if !publisher.discovered[values.Prm] {
+		if err := publisher.publishDiscovery(values.Prm); err != nil {
+			return err
+		}
+		publisher.discovered[values.Prm] = true
+	}
+
+	for _, spec := range fieldSpecs {
+		topic := fmt.Sprintf("%s/%s/%s", publisher.topicPrefix, values.Prm, spec.name)
+		token := publisher.client.Publish(topic, 0, true, spec.value(values))
+		if token.Wait() && token.Error() != nil {
+			return token.Error()
+		}
+	}
+	return nil
+}
+
+// discoveryConfig mirrors the subset of the Home Assistant MQTT sensor
+// discovery schema this exporter needs.
+type discoveryConfig struct {
+	Name              string `json:"name"`
+	UniqueID          string `json:"unique_id"`
+	StateTopic        string `json:"state_topic"`
+	UnitOfMeasurement string `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string `json:"device_class,omitempty"`
+	StateClass        string `json:"state_class,omitempty"`
+	Device            struct {
+		Identifiers []string `json:"identifiers"`
+		Name        string   `json:"name"`
+		Manufacturer string  `json:"manufacturer"`
+	} `json:"device"`
+}
+
+func (publisher *Publisher) publishDiscovery(prm string) error {
+	for _, spec := range fieldSpecs {
+		config := discoveryConfig{
+			Name:              fmt.Sprintf("Linky %s %s", prm, spec.name),
+			UniqueID:          fmt.Sprintf("linky_%s_%s", prm, spec.name),
+			StateTopic:        fmt.Sprintf("%s/%s/%s", publisher.topicPrefix, prm, spec.name),
+			UnitOfMeasurement: spec.unit,
+			DeviceClass:       spec.deviceClass,
+			StateClass:        spec.stateClass,
+		}
+		config.Device.Identifiers = []string{fmt.Sprintf("linky_%s", prm)}
+		config.Device.Name = fmt.Sprintf("Linky %s", prm)
+		config.Device.Manufacturer = "Enedis"
+
+		payload, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+
+		topic := fmt.Sprintf("%s/sensor/linky_%s_%s/config", publisher.discoveryPrefix, prm, spec.name)
+		token := publisher.client.Publish(topic, 0, true, payload)
+		if token.Wait() && token.Error() != nil {
+			return token.Error()
+		}
+	}
+	return nil
+}